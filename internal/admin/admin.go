@@ -0,0 +1,380 @@
+// Package admin exposes the /admin route group: claim revocation, stock
+// refill, bulk promotional grants, and the audit log those mutations write
+// to. Every mutating handler is transactional and, given an
+// Idempotency-Key header, safe to retry without double-applying.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"flash-sale-coupon-system/internal/auth"
+	"flash-sale-coupon-system/internal/claimengine"
+	"flash-sale-coupon-system/internal/database"
+	"flash-sale-coupon-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// allowedSortColumns whitelists the columns ListCoupons may order by, since
+// the sort field comes from an untrusted query parameter.
+var allowedSortColumns = map[string]bool{
+	"id": true, "name": true, "amount": true, "status": true, "created_at": true,
+}
+
+type Handler struct {
+	db     *gorm.DB
+	driver database.Driver
+	engine *claimengine.Engine
+}
+
+func NewHandler(db *gorm.DB, driver database.Driver, engine *claimengine.Engine) *Handler {
+	return &Handler{db: db, driver: driver, engine: engine}
+}
+
+// RevokeClaimRequest is the body for POST /admin/coupons/:name/revoke-claim.
+type RevokeClaimRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// RevokeClaim deletes a user's claim and hands their stock slot back to the pool.
+func (h *Handler) RevokeClaim(c *gin.Context) {
+	couponName := c.Param("name")
+
+	var req RevokeClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	replayed, err := h.replayIfIdempotent(c, "revoke_claim", couponName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency: " + err.Error()})
+		return
+	}
+	if replayed {
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var coupon models.Coupon
+		if err := tx.Where("name = ?", couponName).First(&coupon).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errNotFound
+			}
+			return err
+		}
+
+		// Scoped to the current period only: a revoke shouldn't touch claim
+		// rows from a period that's already been refilled past, or it would
+		// undo the claim-history preservation a refill is supposed to keep.
+		result := tx.Where("coupon_name = ? AND user_id = ? AND period = ?", couponName, req.UserID, coupon.CurrentPeriod).Delete(&models.Claim{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errNotFound
+		}
+
+		if h.engine != nil && h.engine.Available() {
+			if err := h.engine.Release(c.Request.Context(), couponName, req.UserID); err != nil {
+				return err
+			}
+		}
+
+		return h.recordAudit(tx, c, "revoke_claim", couponName, req)
+	})
+	if err != nil {
+		if err == errNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
+			return
+		}
+		if errors.Is(err, errIdempotentConflict) {
+			h.respondIdempotentConflict(c, "revoke_claim", couponName)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke claim: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RefillRequest is the body for POST /admin/coupons/:name/refill.
+type RefillRequest struct {
+	Amount      int    `json:"amount" binding:"required,min=1"`
+	Description string `json:"description"`
+}
+
+// Refill bumps a coupon's stock and logs why.
+func (h *Handler) Refill(c *gin.Context) {
+	couponName := c.Param("name")
+
+	var req RefillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	replayed, err := h.replayIfIdempotent(c, "refill", couponName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency: " + err.Error()})
+		return
+	}
+	if replayed {
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var coupon models.Coupon
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("name = ?", couponName).First(&coupon).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errNotFound
+			}
+			return err
+		}
+
+		if err := tx.Model(&coupon).Update("amount", gorm.Expr("amount + ?", req.Amount)).Error; err != nil {
+			return err
+		}
+
+		if h.engine != nil && h.engine.Available() {
+			if err := h.engine.IncrementRemaining(c.Request.Context(), couponName, req.Amount); err != nil {
+				return err
+			}
+		}
+
+		return h.recordAudit(tx, c, "refill", couponName, req)
+	})
+	if err != nil {
+		if err == errNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+			return
+		}
+		if errors.Is(err, errIdempotentConflict) {
+			h.respondIdempotentConflict(c, "refill", couponName)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refill coupon: " + err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// BulkGrantRequest is the body for POST /admin/coupons/:name/bulk-grant.
+type BulkGrantRequest struct {
+	UserIDs []string `json:"user_ids" binding:"required,min=1"`
+}
+
+// BulkGrant pre-seeds claims for a list of users, e.g. a promotional coupon
+// handed to every existing user. Grants bypass the normal stock check;
+// users who already claimed are skipped rather than treated as an error.
+func (h *Handler) BulkGrant(c *gin.Context) {
+	couponName := c.Param("name")
+
+	var req BulkGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	replayed, err := h.replayIfIdempotent(c, "bulk_grant", couponName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency: " + err.Error()})
+		return
+	}
+	if replayed {
+		return
+	}
+
+	var coupon models.Coupon
+	if err := h.db.Where("name = ?", couponName).First(&coupon).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coupon"})
+		return
+	}
+
+	granted := 0
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		for _, userID := range req.UserIDs {
+			claim := models.Claim{UserID: userID, CouponName: couponName, Period: coupon.CurrentPeriod}
+			if err := tx.Create(&claim).Error; err != nil {
+				if h.driver.IsUniqueViolation(err) {
+					continue // already claimed, grant is a no-op for this user
+				}
+				return err
+			}
+			granted++
+
+			if h.engine != nil && h.engine.Available() {
+				if err := h.engine.MarkClaimed(c.Request.Context(), couponName, userID); err != nil {
+					return err
+				}
+			}
+		}
+
+		return h.recordAudit(tx, c, "bulk_grant", couponName, gin.H{"user_ids": req.UserIDs, "granted": granted})
+	})
+	if err != nil {
+		if errors.Is(err, errIdempotentConflict) {
+			h.respondIdempotentConflict(c, "bulk_grant", couponName)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bulk grant: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"granted": granted, "requested": len(req.UserIDs)})
+}
+
+// ListCoupons handles GET /admin/coupons with pagination and sorting.
+func (h *Handler) ListCoupons(c *gin.Context) {
+	page, pageSize := paginationParams(c)
+
+	sortBy := c.DefaultQuery("sort", "id")
+	if !allowedSortColumns[sortBy] {
+		sortBy = "id"
+	}
+
+	var total int64
+	if err := h.db.Model(&models.Coupon{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count coupons"})
+		return
+	}
+
+	var coupons []models.Coupon
+	if err := h.db.Order(sortBy).Offset((page - 1) * pageSize).Limit(pageSize).Find(&coupons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coupons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": coupons, "page": page, "page_size": pageSize, "total": total})
+}
+
+// ListAuditLogs handles GET /admin/audit, newest first.
+func (h *Handler) ListAuditLogs(c *gin.Context) {
+	page, pageSize := paginationParams(c)
+
+	var total int64
+	if err := h.db.Model(&models.AuditLog{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count audit logs"})
+		return
+	}
+
+	var logs []models.AuditLog
+	if err := h.db.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": logs, "page": page, "page_size": pageSize, "total": total})
+}
+
+func paginationParams(c *gin.Context) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	return page, pageSize
+}
+
+// replayIfIdempotent checks whether a mutation with the same Idempotency-Key
+// header already ran. If so, it writes the 200 response itself and returns
+// true so the caller can skip reapplying the mutation. This is a fast-path
+// check only; the idx_audit_idempotency unique index is what actually
+// prevents a double-apply when two requests race past it concurrently.
+func (h *Handler) replayIfIdempotent(c *gin.Context, action, target string) (bool, error) {
+	key := idempotencyKey(c)
+	if key == nil {
+		return false, nil
+	}
+
+	var existing models.AuditLog
+	err := h.db.Where("action = ? AND target = ? AND idempotency_key = ?", action, target, *key).First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "idempotent_replay": true})
+	return true, nil
+}
+
+// respondIdempotentConflict handles the race where two requests with the
+// same Idempotency-Key both passed replayIfIdempotent's check and one lost
+// the unique-index insert: replay the winner's audit row if it's visible
+// yet, or report a conflict for the caller to retry otherwise.
+func (h *Handler) respondIdempotentConflict(c *gin.Context, action, target string) {
+	replayed, err := h.replayIfIdempotent(c, action, target)
+	if err == nil && replayed {
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already being processed"})
+}
+
+// errIdempotentConflict is returned from inside a transaction when
+// recordAudit hits the idx_audit_idempotency unique index, meaning a
+// concurrent request with the same key already committed (or is about to).
+var errIdempotentConflict = errors.New("admin: idempotency key already used for this action/target")
+
+// recordAudit is called last in every mutating transaction: folding the
+// claim-engine call and the audit insert into the same transaction means a
+// failure in either rolls back the whole mutation, so a retry redoes
+// everything instead of replaying a stale "ok" while Redis is still out of
+// sync with Postgres.
+func (h *Handler) recordAudit(tx *gorm.DB, c *gin.Context, action, target string, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	entry := models.AuditLog{
+		Actor:          actor(c),
+		Action:         action,
+		Target:         target,
+		Payload:        raw,
+		IdempotencyKey: idempotencyKey(c),
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		if h.driver.IsUniqueViolation(err) {
+			return errIdempotentConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// idempotencyKey returns nil when the header is absent so the AuditLog
+// column stores NULL rather than "", keeping keyless mutations out of the
+// idx_audit_idempotency unique index.
+func idempotencyKey(c *gin.Context) *string {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		return nil
+	}
+	return &key
+}
+
+func actor(c *gin.Context) string {
+	if auth.Authenticated(c) {
+		return auth.UserID(c)
+	}
+	return "unknown"
+}
+
+var errNotFound = gorm.ErrRecordNotFound
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func signToken(t *testing.T, claims Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func runMiddleware(t *testing.T, authHeader string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Middleware(testSecret))
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"user_id": UserID(c),
+			"brand":   Brand(c),
+			"admin":   IsAdmin(c),
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareValidToken(t *testing.T) {
+	token := signToken(t, Claims{
+		UserID: "user-1",
+		Roles:  []string{"admin"},
+		Brand:  "acme",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+
+	w := runMiddleware(t, "Bearer "+token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMiddlewareExpiredToken(t *testing.T) {
+	token := signToken(t, Claims{
+		UserID: "user-1",
+		Brand:  "acme",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	})
+
+	w := runMiddleware(t, "Bearer "+token)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for expired token, got %d", w.Code)
+	}
+}
+
+func TestMiddlewareMissingHeader(t *testing.T) {
+	w := runMiddleware(t, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for missing Authorization header, got %d", w.Code)
+	}
+}
+
+func TestRequireAdminRejectsNonAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token := signToken(t, Claims{
+		UserID: "user-1",
+		Roles:  []string{"member"},
+		Brand:  "acme",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+
+	router := gin.New()
+	router.Use(Middleware(testSecret), RequireAdmin())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin, got %d", w.Code)
+	}
+}
@@ -0,0 +1,311 @@
+// Package claimengine fronts Postgres with Redis so that coupon claims under
+// flash-sale load are a single atomic operation instead of a per-request
+// SELECT/SELECT/COUNT/INSERT transaction. Stock is held in Redis as a decrementing
+// counter plus a set of claimed user IDs, both checked and updated by one Lua
+// script. Successful claims are queued and persisted to Postgres asynchronously
+// by a background writer, which remains the durable source of truth.
+package claimengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"flash-sale-coupon-system/internal/database"
+	"flash-sale-coupon-system/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Status is the outcome of a claim attempt against the Redis pipeline.
+type Status int
+
+const (
+	StatusOK Status = iota
+	StatusAlreadyClaimed
+	StatusExhausted
+	StatusNotFound
+)
+
+// ErrUnavailable is returned by Engine methods when the Redis ping in
+// NewEngine failed; a Redis error after a successful ping is returned as a
+// wrapped error instead. Callers should fall back to the existing GORM
+// transaction path.
+var ErrUnavailable = errors.New("claimengine: redis unavailable, use fallback path")
+
+const (
+	remainingKeyPrefix = "coupon:%s:remaining"
+	claimedKeyPrefix   = "coupon:%s:claimed"
+)
+
+// claimScript atomically checks for an existing coupon and claim, then
+// decrements remaining stock and records the claim. Return codes:
+//
+//	 1 - claimed successfully
+//	 0 - user already claimed
+//	-1 - coupon not found
+//	-2 - stock exhausted
+var claimScript = redis.NewScript(`
+local remainingKey = KEYS[1]
+local claimedKey = KEYS[2]
+local userID = ARGV[1]
+
+if redis.call("EXISTS", remainingKey) == 0 then
+	return -1
+end
+
+if redis.call("SISMEMBER", claimedKey, userID) == 1 then
+	return 0
+end
+
+local remaining = tonumber(redis.call("GET", remainingKey))
+if remaining <= 0 then
+	return -2
+end
+
+redis.call("DECR", remainingKey)
+redis.call("SADD", claimedKey, userID)
+return 1
+`)
+
+// releaseScript is the inverse of claimScript: it un-claims a user and hands
+// their slot back to the pool, used when an admin revokes a claim.
+var releaseScript = redis.NewScript(`
+local remainingKey = KEYS[1]
+local claimedKey = KEYS[2]
+local userID = ARGV[1]
+
+if redis.call("SISMEMBER", claimedKey, userID) == 0 then
+	return 0
+end
+
+redis.call("SREM", claimedKey, userID)
+redis.call("INCR", remainingKey)
+return 1
+`)
+
+// queueSize bounds the async persistence buffer; Claim blocks once it fills,
+// which is an intentional backpressure valve rather than an unbounded queue.
+const queueSize = 4096
+
+// Engine holds the Redis client and the background writer that persists
+// claims to Postgres. Construct one with NewEngine and call Warmup once at
+// startup to rebuild Redis state from durable storage.
+type Engine struct {
+	db     *gorm.DB
+	driver database.Driver
+	redis  *redis.Client
+	queue  chan pendingClaim
+	logger *log.Logger
+
+	mu        sync.RWMutex
+	available bool
+}
+
+type pendingClaim struct {
+	CouponName string
+	UserID     string
+	Period     int
+}
+
+// NewEngine connects to Redis at addr and starts the background writer. If
+// the initial ping fails, the Engine stays in a degraded state where Claim
+// and CreateCoupon return ErrUnavailable so callers fall back to the existing
+// GORM transaction path; it does not retry the connection on its own. driver
+// is used by the writer to recognize a genuine duplicate-claim race against
+// Postgres instead of retrying it forever.
+func NewEngine(db *gorm.DB, driver database.Driver, addr string) *Engine {
+	e := &Engine{
+		db:     db,
+		driver: driver,
+		redis:  redis.NewClient(&redis.Options{Addr: addr}),
+		queue:  make(chan pendingClaim, queueSize),
+		logger: log.New(log.Writer(), "[claimengine] ", log.LstdFlags),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := e.redis.Ping(ctx).Err(); err != nil {
+		e.logger.Printf("redis unavailable, falling back to direct DB path: %v", err)
+		e.setAvailable(false)
+	} else {
+		e.setAvailable(true)
+	}
+
+	go e.runWriter()
+
+	return e
+}
+
+// Available reports whether the Redis fast path can currently be used.
+func (e *Engine) Available() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.available
+}
+
+func (e *Engine) setAvailable(v bool) {
+	e.mu.Lock()
+	e.available = v
+	e.mu.Unlock()
+}
+
+// Warmup rebuilds Redis state from Postgres. Call it once on startup so the
+// fast path reflects durable state after a restart or cache eviction.
+func (e *Engine) Warmup(ctx context.Context) error {
+	if !e.Available() {
+		return ErrUnavailable
+	}
+
+	var coupons []models.Coupon
+	if err := e.db.Find(&coupons).Error; err != nil {
+		return fmt.Errorf("claimengine: warmup load coupons: %w", err)
+	}
+
+	for _, coupon := range coupons {
+		var claims []models.Claim
+		if err := e.db.Where("coupon_name = ? AND period = ?", coupon.Name, coupon.CurrentPeriod).Find(&claims).Error; err != nil {
+			return fmt.Errorf("claimengine: warmup load claims for %s: %w", coupon.Name, err)
+		}
+
+		remaining := coupon.Amount - len(claims)
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		claimedKey := claimedKey(coupon.Name)
+		pipe := e.redis.TxPipeline()
+		pipe.Set(ctx, remainingKey(coupon.Name), remaining, 0)
+		pipe.Del(ctx, claimedKey)
+		for _, claim := range claims {
+			pipe.SAdd(ctx, claimedKey, claim.UserID)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("claimengine: warmup seed %s: %w", coupon.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CreateCoupon seeds Redis with the initial remaining count for a new coupon.
+// Callers are still responsible for persisting the Coupon row via GORM; this
+// only primes the fast path so claims don't have to wait on Postgres.
+func (e *Engine) CreateCoupon(ctx context.Context, name string, amount int) error {
+	if !e.Available() {
+		return ErrUnavailable
+	}
+
+	pipe := e.redis.TxPipeline()
+	pipe.Set(ctx, remainingKey(name), amount, 0)
+	pipe.Del(ctx, claimedKey(name))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("claimengine: seed %s: %w", name, err)
+	}
+	return nil
+}
+
+// Claim runs the atomic claim script and, on success, enqueues the claim for
+// asynchronous persistence to Postgres. period is the coupon's CurrentPeriod
+// at the time the caller looked it up, and is stamped onto the persisted
+// claim so a later refill doesn't collide with it.
+func (e *Engine) Claim(ctx context.Context, couponName, userID string, period int) (Status, error) {
+	if !e.Available() {
+		return StatusNotFound, ErrUnavailable
+	}
+
+	result, err := claimScript.Run(ctx, e.redis, []string{remainingKey(couponName), claimedKey(couponName)}, userID).Int()
+	if err != nil {
+		return StatusNotFound, fmt.Errorf("claimengine: claim script: %w", err)
+	}
+
+	switch result {
+	case 1:
+		e.queue <- pendingClaim{CouponName: couponName, UserID: userID, Period: period}
+		return StatusOK, nil
+	case 0:
+		return StatusAlreadyClaimed, nil
+	case -2:
+		return StatusExhausted, nil
+	default:
+		return StatusNotFound, nil
+	}
+}
+
+// Release un-claims a user and hands their slot back to the pool. Used by
+// the admin revoke-claim path to keep Redis consistent with the Postgres
+// deletion of the Claim row.
+func (e *Engine) Release(ctx context.Context, couponName, userID string) error {
+	if !e.Available() {
+		return ErrUnavailable
+	}
+
+	if _, err := releaseScript.Run(ctx, e.redis, []string{remainingKey(couponName), claimedKey(couponName)}, userID).Int(); err != nil {
+		return fmt.Errorf("claimengine: release script: %w", err)
+	}
+	return nil
+}
+
+// IncrementRemaining adds delta to a coupon's remaining stock, used by the
+// admin refill path.
+func (e *Engine) IncrementRemaining(ctx context.Context, couponName string, delta int) error {
+	if !e.Available() {
+		return ErrUnavailable
+	}
+
+	if err := e.redis.IncrBy(ctx, remainingKey(couponName), int64(delta)).Err(); err != nil {
+		return fmt.Errorf("claimengine: increment remaining %s: %w", couponName, err)
+	}
+	return nil
+}
+
+// MarkClaimed records a user as having claimed a coupon without touching
+// remaining stock, used when an admin grants a coupon directly (e.g. a bulk
+// promotional grant) outside the normal claim flow.
+func (e *Engine) MarkClaimed(ctx context.Context, couponName, userID string) error {
+	if !e.Available() {
+		return ErrUnavailable
+	}
+
+	if err := e.redis.SAdd(ctx, claimedKey(couponName), userID).Err(); err != nil {
+		return fmt.Errorf("claimengine: mark claimed %s/%s: %w", couponName, userID, err)
+	}
+	return nil
+}
+
+// runWriter drains the queue and persists successful claims to Postgres. A
+// failed write is retried rather than dropped so Postgres eventually
+// converges with the Redis state that already told the user they'd won. A
+// unique-constraint violation means the row is already there (e.g. a replay
+// after a crash mid-write), so it's treated as success rather than retried
+// forever.
+func (e *Engine) runWriter() {
+	for pending := range e.queue {
+		claim := models.Claim{
+			UserID:     pending.UserID,
+			CouponName: pending.CouponName,
+			Period:     pending.Period,
+		}
+
+		for {
+			err := e.db.Create(&claim).Error
+			if err == nil || e.driver.IsUniqueViolation(err) {
+				break
+			}
+			e.logger.Printf("persist claim %s/%s failed, retrying: %v", pending.CouponName, pending.UserID, err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func remainingKey(name string) string {
+	return fmt.Sprintf(remainingKeyPrefix, name)
+}
+
+func claimedKey(name string) string {
+	return fmt.Sprintf(claimedKeyPrefix, name)
+}
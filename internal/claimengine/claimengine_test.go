@@ -0,0 +1,213 @@
+package claimengine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"flash-sale-coupon-system/internal/database"
+	"flash-sale-coupon-system/internal/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+}
+
+func (fakeDriver) IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	return true
+}
+
+func (fakeDriver) MigrateHook(db *gorm.DB) error {
+	return nil
+}
+
+func newTestEngine(t testing.TB) *Engine {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	return newEngineAgainstAddr(t, mr.Addr())
+}
+
+func newEngineAgainstAddr(t testing.TB, addr string) *Engine {
+	t.Helper()
+
+	var driver database.Driver = fakeDriver{}
+	db, err := driver.Open(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Coupon{}, &models.Claim{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	return NewEngine(db, driver, addr)
+}
+
+func TestClaimRejectsSecondClaimBySameUser(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+
+	if err := e.CreateCoupon(ctx, "summer", 10); err != nil {
+		t.Fatalf("seed coupon: %v", err)
+	}
+
+	status, err := e.Claim(ctx, "summer", "user-1", 0)
+	if err != nil {
+		t.Fatalf("first claim: %v", err)
+	}
+	if status != StatusOK {
+		t.Fatalf("expected StatusOK, got %v", status)
+	}
+
+	status, err = e.Claim(ctx, "summer", "user-1", 0)
+	if err != nil {
+		t.Fatalf("second claim: %v", err)
+	}
+	if status != StatusAlreadyClaimed {
+		t.Fatalf("expected StatusAlreadyClaimed, got %v", status)
+	}
+}
+
+func TestClaimExhaustsStock(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+
+	if err := e.CreateCoupon(ctx, "summer", 1); err != nil {
+		t.Fatalf("seed coupon: %v", err)
+	}
+
+	if status, err := e.Claim(ctx, "summer", "user-1", 0); err != nil || status != StatusOK {
+		t.Fatalf("expected first claim to succeed, got status=%v err=%v", status, err)
+	}
+
+	status, err := e.Claim(ctx, "summer", "user-2", 0)
+	if err != nil {
+		t.Fatalf("second claim: %v", err)
+	}
+	if status != StatusExhausted {
+		t.Fatalf("expected StatusExhausted, got %v", status)
+	}
+}
+
+// BenchmarkClaimConcurrent exercises the Redis fast path under concurrent
+// load. It uses miniredis rather than a real Redis server, so absolute
+// numbers aren't representative of production hardware - see
+// BenchmarkClaimConcurrentRealRedis for that - but it's the same code path
+// and catches throughput regressions (e.g. accidental serialization) in CI,
+// where a live Redis isn't available.
+func BenchmarkClaimConcurrent(b *testing.B) {
+	e := newTestEngine(b)
+	ctx := context.Background()
+
+	if err := e.CreateCoupon(ctx, "summer", b.N); err != nil {
+		b.Fatalf("seed coupon: %v", err)
+	}
+
+	b.ResetTimer()
+
+	var wg sync.WaitGroup
+	var counter int
+	var mu sync.Mutex
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			userID := fmt.Sprintf("user-%d", counter)
+			counter++
+			mu.Unlock()
+
+			if _, err := e.Claim(ctx, "summer", userID, 0); err != nil {
+				b.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkClaimConcurrentRealRedis is the real counterpart to
+// BenchmarkClaimConcurrent: it demonstrates this request's "10k+ claims/sec"
+// target against an actual Redis server rather than the in-process
+// miniredis fake. It's skipped unless REDIS_ADDR points at one, since CI and
+// local `go test` runs shouldn't depend on a live Redis being reachable.
+func BenchmarkClaimConcurrentRealRedis(b *testing.B) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		b.Skip("REDIS_ADDR not set, skipping real-Redis benchmark")
+	}
+
+	e := newEngineAgainstAddr(b, addr)
+	if !e.Available() {
+		b.Fatalf("could not connect to Redis at %s", addr)
+	}
+	ctx := context.Background()
+
+	if err := e.CreateCoupon(ctx, "summer", b.N); err != nil {
+		b.Fatalf("seed coupon: %v", err)
+	}
+
+	b.ResetTimer()
+
+	var wg sync.WaitGroup
+	var counter int
+	var mu sync.Mutex
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			userID := fmt.Sprintf("user-%d", counter)
+			counter++
+			mu.Unlock()
+
+			if _, err := e.Claim(ctx, "summer", userID, 0); err != nil {
+				b.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWarmupSeedsFromCurrentPeriodOnly(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+
+	coupon := models.Coupon{Name: "summer", Amount: 1, CurrentPeriod: 1}
+	if err := e.db.Create(&coupon).Error; err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	if err := e.db.Create(&models.Claim{UserID: "stale-user", CouponName: "summer", Period: 0}).Error; err != nil {
+		t.Fatalf("create stale claim: %v", err)
+	}
+
+	if err := e.Warmup(ctx); err != nil {
+		t.Fatalf("warmup: %v", err)
+	}
+
+	status, err := e.Claim(ctx, "summer", "new-user", 1)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if status != StatusOK {
+		t.Fatalf("expected warmup to leave period-1 stock unclaimed, got %v", status)
+	}
+
+	time.Sleep(50 * time.Millisecond) // let the async writer persist the claim
+}
@@ -10,7 +10,11 @@ import (
 
 type Config struct {
 	DatabaseURL string
+	DBDriver    string
 	Port        string
+	RedisAddr   string
+	AuthEnabled bool
+	JWTSecret   string
 }
 
 func Load() *Config {
@@ -19,61 +23,94 @@ func Load() *Config {
 		log.Println("No .env file found, using environment variables or defaults")
 	}
 
+	dbDefault := os.Getenv("DB_DEFAULT")
+	if dbDefault == "" {
+		dbDefault = "postgresql"
+	}
+
 	// Build database URL from individual variables or use DATABASE_URL if provided
-	databaseURL := buildDatabaseURL()
+	databaseURL := buildDatabaseURL(dbDefault)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	authEnabled := os.Getenv("AUTH_ENABLED") == "true"
+
 	return &Config{
 		DatabaseURL: databaseURL,
+		DBDriver:    normalizeDriver(dbDefault),
 		Port:        port,
+		RedisAddr:   redisAddr,
+		AuthEnabled: authEnabled,
+		JWTSecret:   os.Getenv("JWT_SECRET"),
+	}
+}
+
+// normalizeDriver maps the DB_DEFAULT value to the driver name that
+// database.NewDriver understands.
+func normalizeDriver(dbDefault string) string {
+	switch dbDefault {
+	case "mysql":
+		return "mysql"
+	case "sqlite", "sqlite3":
+		return "sqlite"
+	default:
+		return "postgres"
 	}
 }
 
-func buildDatabaseURL() string {
+func buildDatabaseURL(dbDefault string) string {
 	// If DATABASE_URL is explicitly set, use it (for backward compatibility and Docker)
 	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
 		return databaseURL
 	}
 
-	// Build from individual database variables
-	dbDefault := os.Getenv("DB_DEFAULT")
-	if dbDefault == "" {
-		dbDefault = "postgresql"
+	switch dbDefault {
+	case "mysql":
+		return buildMySQLDSN()
+	case "sqlite", "sqlite3":
+		return buildSQLiteDSN()
+	default:
+		return buildPostgresDSN()
 	}
+}
 
-	// Only build PostgreSQL connection string if DB_DEFAULT is postgresql
-	if dbDefault == "postgresql" {
-		host := os.Getenv("DB_PG_HOST")
-		if host == "" {
-			host = "localhost"
-		}
-
-		database := os.Getenv("DB_PG_DATABASE")
-		if database == "" {
-			database = "coupon_db"
-		}
-
-		username := os.Getenv("DB_PG_USERNAME")
-		if username == "" {
-			username = "postgres"
-		}
-
-		password := os.Getenv("DB_PG_PASSWORD")
-
-		port := os.Getenv("DB_PG_PORT")
-		if port == "" {
-			port = "5432"
-		}
-
-		// Build PostgreSQL connection string
-		return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-			host, username, password, database, port)
-	}
+func buildPostgresDSN() string {
+	host := getenvDefault("DB_PG_HOST", "localhost")
+	database := getenvDefault("DB_PG_DATABASE", "coupon_db")
+	username := getenvDefault("DB_PG_USERNAME", "postgres")
+	password := os.Getenv("DB_PG_PASSWORD")
+	port := getenvDefault("DB_PG_PORT", "5432")
+
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		host, username, password, database, port)
+}
 
-	// Default fallback
-	return "host=localhost user=postgres password=postgres dbname=coupon_db port=5432 sslmode=disable"
+func buildMySQLDSN() string {
+	host := getenvDefault("DB_MYSQL_HOST", "localhost")
+	port := getenvDefault("DB_MYSQL_PORT", "3306")
+	database := getenvDefault("DB_MYSQL_DATABASE", "coupon_db")
+	username := getenvDefault("DB_MYSQL_USERNAME", "root")
+	password := os.Getenv("DB_MYSQL_PASSWORD")
+
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		username, password, host, port, database)
+}
+
+func buildSQLiteDSN() string {
+	return getenvDefault("DB_SQLITE_PATH", "coupon.db")
+}
+
+func getenvDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
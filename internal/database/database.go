@@ -1,26 +1,70 @@
 package database
 
 import (
+	"fmt"
+
 	"flash-sale-coupon-system/internal/models"
 
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// Initialize creates a new database connection
-func Initialize(databaseURL string) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
+// Driver abstracts the differences between supported database backends:
+// opening a connection and recognizing a driver-specific unique-constraint
+// violation.
+type Driver interface {
+	Open(dsn string) (*gorm.DB, error)
+	IsUniqueViolation(err error) bool
+	// MigrateHook runs after AutoMigrate for anything a driver needs that
+	// GORM's generic migrator doesn't handle (e.g. composite index syntax).
+	MigrateHook(db *gorm.DB) error
+}
+
+// NewDriver resolves a Driver by name: "postgres", "mysql", or "sqlite".
+func NewDriver(name string) (Driver, error) {
+	switch name {
+	case "postgres", "postgresql":
+		return postgresDriver{}, nil
+	case "mysql":
+		return mysqlDriver{}, nil
+	case "sqlite", "sqlite3":
+		return sqliteDriver{}, nil
+	default:
+		return nil, fmt.Errorf("database: unknown driver %q", name)
+	}
+}
+
+// Initialize resolves the named driver and opens a connection with it.
+func Initialize(driverName, dsn string) (*gorm.DB, Driver, error) {
+	driver, err := NewDriver(driverName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return db, nil
+	db, err := driver.Open(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, driver, nil
 }
 
 // Migrate runs database migrations
-func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+func Migrate(db *gorm.DB, driver Driver) error {
+	if err := db.AutoMigrate(
 		&models.Coupon{},
 		&models.Claim{},
-	)
+		&models.AuditLog{},
+	); err != nil {
+		return err
+	}
+
+	if err := driver.MigrateHook(db); err != nil {
+		return err
+	}
+
+	// Backfill rows that predate the Status column; AutoMigrate's column
+	// default only applies to new inserts, not existing ones.
+	return db.Model(&models.Coupon{}).
+		Where("status = ? OR status IS NULL", "").
+		Update("status", models.StatusActive).Error
 }
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"flash-sale-coupon-system/internal/models"
+)
+
+// driverCases is the test matrix: the same assertions run against every
+// backend the Driver interface supports. sqlite needs no external server so
+// it always runs; postgres and mysql only run when a live DSN is provided,
+// matching how this suite would be wired into a CI test-matrix job.
+func driverCases(t *testing.T) []struct {
+	name   string
+	driver Driver
+	dsn    string
+} {
+	t.Helper()
+
+	cases := []struct {
+		name   string
+		driver Driver
+		dsn    string
+	}{
+		{name: "sqlite", driver: sqliteDriver{}, dsn: ":memory:"},
+	}
+
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		cases = append(cases, struct {
+			name   string
+			driver Driver
+			dsn    string
+		}{name: "postgres", driver: postgresDriver{}, dsn: dsn})
+	}
+
+	if dsn := os.Getenv("TEST_MYSQL_DSN"); dsn != "" {
+		cases = append(cases, struct {
+			name   string
+			driver Driver
+			dsn    string
+		}{name: "mysql", driver: mysqlDriver{}, dsn: dsn})
+	}
+
+	return cases
+}
+
+func TestDriverIsUniqueViolation(t *testing.T) {
+	for _, tc := range driverCases(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := tc.driver.Open(tc.dsn)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if err := db.AutoMigrate(&models.Coupon{}); err != nil {
+				t.Fatalf("automigrate: %v", err)
+			}
+
+			coupon := models.Coupon{Name: "dup-test", Amount: 1}
+			if err := db.Create(&coupon).Error; err != nil {
+				t.Fatalf("create first coupon: %v", err)
+			}
+
+			dupe := models.Coupon{Name: "dup-test", Amount: 1}
+			err = db.Create(&dupe).Error
+			if err == nil {
+				t.Fatal("expected a unique-constraint error on duplicate coupon name")
+			}
+
+			if !tc.driver.IsUniqueViolation(err) {
+				t.Fatalf("IsUniqueViolation did not recognize duplicate-name error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewDriverUnknownName(t *testing.T) {
+	if _, err := NewDriver("oracle"); err == nil {
+		t.Fatal("expected an error for an unsupported driver name")
+	}
+}
+
+func TestNewDriverKnownNames(t *testing.T) {
+	for _, name := range []string{"postgres", "postgresql", "mysql", "sqlite", "sqlite3"} {
+		if _, err := NewDriver(name); err != nil {
+			t.Fatalf("NewDriver(%q): %v", name, err)
+		}
+	}
+}
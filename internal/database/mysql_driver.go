@@ -0,0 +1,33 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type mysqlDriver struct{}
+
+func (mysqlDriver) Open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+}
+
+func (mysqlDriver) IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062 // ER_DUP_ENTRY
+	}
+
+	return strings.Contains(strings.ToLower(err.Error()), "duplicate entry")
+}
+
+func (mysqlDriver) MigrateHook(db *gorm.DB) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type sqliteDriver struct{}
+
+func (sqliteDriver) Open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+}
+
+func (sqliteDriver) IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (sqliteDriver) MigrateHook(db *gorm.DB) error {
+	return nil
+}
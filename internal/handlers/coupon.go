@@ -3,32 +3,47 @@ package handlers
 import (
 	"errors"
 	"net/http"
-	"strings"
+	"time"
 
-	"ubersnap/internal/models"
+	"flash-sale-coupon-system/internal/auth"
+	"flash-sale-coupon-system/internal/claimengine"
+	"flash-sale-coupon-system/internal/database"
+	"flash-sale-coupon-system/internal/models"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
 type CouponHandler struct {
-	db *gorm.DB
+	db     *gorm.DB
+	driver database.Driver
+	engine *claimengine.Engine
 }
 
-func NewCouponHandler(db *gorm.DB) *CouponHandler {
-	return &CouponHandler{db: db}
+// NewCouponHandler wires in the database driver (for unique-constraint
+// detection) and, when engine is non-nil, the Redis-backed claim engine so
+// ClaimCoupon and CreateCoupon use the fast path, falling back to the plain
+// GORM transaction whenever the engine reports itself unavailable.
+func NewCouponHandler(db *gorm.DB, driver database.Driver, engine *claimengine.Engine) *CouponHandler {
+	return &CouponHandler{db: db, driver: driver, engine: engine}
 }
 
 // CreateCouponRequest represents the request body for creating a coupon
 type CreateCouponRequest struct {
 	Name   string `json:"name" binding:"required"`
 	Amount int    `json:"amount" binding:"required,min=0"`
+	// Brand is honored for admin callers and when auth is disabled;
+	// authenticated non-admin callers always get their own JWT brand
+	// regardless of what they send here.
+	Brand string `json:"brand"`
 }
 
-// ClaimCouponRequest represents the request body for claiming a coupon
+// ClaimCouponRequest represents the request body for claiming a coupon.
+// UserID is honored for admin callers claiming on another user's behalf and
+// is required when auth is disabled; other authenticated callers have their
+// user ID derived from the JWT.
 type ClaimCouponRequest struct {
-	UserID     string `json:"user_id" binding:"required"`
+	UserID     string `json:"user_id"`
 	CouponName string `json:"coupon_name" binding:"required"`
 }
 
@@ -40,9 +55,15 @@ func (h *CouponHandler) CreateCoupon(c *gin.Context) {
 		return
 	}
 
+	brand := req.Brand
+	if auth.Authenticated(c) && !auth.IsAdmin(c) {
+		brand = auth.Brand(c)
+	}
+
 	coupon := models.Coupon{
 		Name:   req.Name,
 		Amount: req.Amount,
+		Brand:  brand,
 	}
 
 	if err := h.db.Create(&coupon).Error; err != nil {
@@ -50,6 +71,13 @@ func (h *CouponHandler) CreateCoupon(c *gin.Context) {
 		return
 	}
 
+	if h.engine != nil && h.engine.Available() {
+		if err := h.engine.CreateCoupon(c.Request.Context(), coupon.Name, coupon.Amount); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to seed claim engine: " + err.Error()})
+			return
+		}
+	}
+
 	c.Status(http.StatusCreated)
 }
 
@@ -61,28 +89,133 @@ func (h *CouponHandler) ClaimCoupon(c *gin.Context) {
 		return
 	}
 
-	// Use transaction to ensure atomicity
+	var coupon models.Coupon
+	if err := h.db.Where("name = ?", req.CouponName).First(&coupon).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coupon"})
+		return
+	}
+
+	if claimErr := checkClaimWindow(&coupon, time.Now()); claimErr != nil {
+		c.JSON(claimErr.StatusCode, gin.H{"error": claimErr.Message})
+		return
+	}
+
+	userID, claimErr := h.resolveClaimUserID(c, req)
+	if claimErr != nil {
+		c.JSON(claimErr.StatusCode, gin.H{"error": claimErr.Message})
+		return
+	}
+
+	if h.engine != nil && h.engine.Available() {
+		h.claimViaEngine(c, req.CouponName, userID, coupon.CurrentPeriod)
+		return
+	}
+
+	h.claimViaTransaction(c, req.CouponName, userID, coupon.CurrentPeriod)
+}
+
+// resolveClaimUserID derives the claiming user's ID from the JWT so a caller
+// cannot spoof a claim on someone else's behalf. Admins may still target a
+// specific user via the request body; when auth is disabled, the body field
+// is used directly, matching the pre-auth behavior.
+func (h *CouponHandler) resolveClaimUserID(c *gin.Context, req ClaimCouponRequest) (string, *ClaimError) {
+	if !auth.Authenticated(c) {
+		if req.UserID == "" {
+			return "", &ClaimError{Message: "user_id is required", StatusCode: http.StatusBadRequest}
+		}
+		return req.UserID, nil
+	}
+
+	if auth.IsAdmin(c) && req.UserID != "" {
+		return req.UserID, nil
+	}
+
+	return auth.UserID(c), nil
+}
+
+// checkClaimWindow rejects claims outside the coupon's active window: 403
+// before the window opens, 410 after it closes. Revoked coupons also get 403
+// and exhausted ones 400; the error message tells the reasons apart.
+func checkClaimWindow(coupon *models.Coupon, now time.Time) *ClaimError {
+	switch coupon.Status {
+	case models.StatusRevoked:
+		return &ClaimError{Message: "Coupon has been revoked", StatusCode: http.StatusForbidden}
+	case models.StatusDraft:
+		return &ClaimError{Message: "Coupon is not yet active", StatusCode: http.StatusForbidden}
+	case models.StatusExpired:
+		return &ClaimError{Message: "Coupon has expired", StatusCode: http.StatusGone}
+	case models.StatusExhausted:
+		return &ClaimError{Message: "Coupon stock exhausted", StatusCode: http.StatusBadRequest}
+	}
+
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return &ClaimError{Message: "Coupon is not yet active", StatusCode: http.StatusForbidden}
+	}
+	if coupon.ExpiresAt != nil && now.After(*coupon.ExpiresAt) {
+		return &ClaimError{Message: "Coupon has expired", StatusCode: http.StatusGone}
+	}
+
+	return nil
+}
+
+// claimViaEngine serves the claim from the Redis-backed claimengine, falling
+// back to the DB transaction when Claim returns ErrUnavailable. Any other
+// engine error, such as a failed script run against Redis, is returned as a
+// 500 without a fallback.
+func (h *CouponHandler) claimViaEngine(c *gin.Context, couponName, userID string, period int) {
+	status, err := h.engine.Claim(c.Request.Context(), couponName, userID, period)
+	if err != nil {
+		if errors.Is(err, claimengine.ErrUnavailable) {
+			h.claimViaTransaction(c, couponName, userID, period)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim coupon: " + err.Error()})
+		return
+	}
+
+	switch status {
+	case claimengine.StatusOK:
+		c.Status(http.StatusOK)
+	case claimengine.StatusAlreadyClaimed:
+		c.JSON(http.StatusConflict, gin.H{"error": "User has already claimed this coupon"})
+	case claimengine.StatusExhausted:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Coupon stock exhausted"})
+	case claimengine.StatusNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+	}
+}
+
+// claimViaTransaction is the original GORM transaction path, used directly
+// when no claim engine is configured and as the fallback when Redis is down.
+// period pins the claim to the coupon's CurrentPeriod at the time it was
+// looked up by the caller, so stock checks only count claims from the
+// current billing period.
+func (h *CouponHandler) claimViaTransaction(c *gin.Context, couponName, userID string, period int) {
 	err := h.db.Transaction(func(tx *gorm.DB) error {
 		// Check if coupon exists
 		var coupon models.Coupon
-		if err := tx.Where("name = ?", req.CouponName).First(&coupon).Error; err != nil {
+		if err := tx.Where("name = ?", couponName).First(&coupon).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return &ClaimError{Message: "Coupon not found", StatusCode: http.StatusNotFound}
 			}
 			return err
 		}
 
-		// Check if user has already claimed this coupon
+		// Check if user has already claimed this coupon in the current period
 		var existingClaim models.Claim
-		if err := tx.Where("user_id = ? AND coupon_name = ?", req.UserID, req.CouponName).First(&existingClaim).Error; err == nil {
+		if err := tx.Where("user_id = ? AND coupon_name = ? AND period = ?", userID, couponName, period).First(&existingClaim).Error; err == nil {
 			return &ClaimError{Message: "User has already claimed this coupon", StatusCode: http.StatusConflict}
 		} else if err != gorm.ErrRecordNotFound {
 			return err
 		}
 
-		// Count existing claims for this coupon
+		// Count existing claims for this coupon in the current period
 		var claimCount int64
-		if err := tx.Model(&models.Claim{}).Where("coupon_name = ?", req.CouponName).Count(&claimCount).Error; err != nil {
+		if err := tx.Model(&models.Claim{}).Where("coupon_name = ? AND period = ?", couponName, period).Count(&claimCount).Error; err != nil {
 			return err
 		}
 
@@ -93,13 +226,14 @@ func (h *CouponHandler) ClaimCoupon(c *gin.Context) {
 
 		// Create claim record
 		claim := models.Claim{
-			UserID:     req.UserID,
-			CouponName: req.CouponName,
+			UserID:     userID,
+			CouponName: couponName,
+			Period:     period,
 		}
 
 		if err := tx.Create(&claim).Error; err != nil {
 			// Check if it's a unique constraint violation (race condition)
-			if isUniqueConstraintError(err) {
+			if h.driver.IsUniqueViolation(err) {
 				return &ClaimError{Message: "User has already claimed this coupon", StatusCode: http.StatusConflict}
 			}
 			return err
@@ -134,9 +268,16 @@ func (h *CouponHandler) GetCouponDetails(c *gin.Context) {
 		return
 	}
 
-	// Get all claims for this coupon
+	if auth.Authenticated(c) && !auth.IsAdmin(c) && coupon.Brand != auth.Brand(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Coupon belongs to a different brand"})
+		return
+	}
+
+	// Get claims for this coupon's current period; a refill advances
+	// CurrentPeriod, so earlier periods' claims no longer count against
+	// remaining stock even though the rows are kept for history.
 	var claims []models.Claim
-	if err := h.db.Where("coupon_name = ?", couponName).Find(&claims).Error; err != nil {
+	if err := h.db.Where("coupon_name = ? AND period = ?", couponName, coupon.CurrentPeriod).Find(&claims).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch claims"})
 		return
 	}
@@ -158,37 +299,101 @@ func (h *CouponHandler) GetCouponDetails(c *gin.Context) {
 		Amount:          coupon.Amount,
 		RemainingAmount: remainingAmount,
 		ClaimedBy:       claimedBy,
+		Status:          coupon.Status,
+		StartsAt:        coupon.StartsAt,
+		ExpiresAt:       coupon.ExpiresAt,
+	}
+
+	if coupon.ExpiresAt != nil {
+		secs := int64(time.Until(*coupon.ExpiresAt).Seconds())
+		if secs < 0 {
+			secs = 0
+		}
+		response.TimeRemainingSecs = &secs
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// ClaimError is a custom error type for claim operations
-type ClaimError struct {
-	Message    string
-	StatusCode int
+// ListCoupons handles GET /api/coupons. Non-admin callers only see coupons
+// matching their JWT's brand claim; admins and unauthenticated callers (when
+// AUTH_ENABLED is off) see everything.
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	query := h.db
+	if auth.Authenticated(c) && !auth.IsAdmin(c) {
+		query = query.Where("brand = ?", auth.Brand(c))
+	}
+
+	var coupons []models.Coupon
+	if err := query.Find(&coupons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coupons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupons)
 }
 
-func (e *ClaimError) Error() string {
-	return e.Message
+// UpdateCouponRequest represents the editable fields for PATCH /api/coupons/:name.
+// Fields are pointers so an omitted field leaves the existing value untouched.
+type UpdateCouponRequest struct {
+	Status         *models.CouponStatus `json:"status"`
+	StartsAt       *time.Time           `json:"starts_at"`
+	ExpiresAt      *time.Time           `json:"expires_at"`
+	BillingPeriods *int                 `json:"billing_periods"`
 }
 
-// isUniqueConstraintError checks if the error is a unique constraint violation
-func isUniqueConstraintError(err error) bool {
-	if err == nil {
-		return false
+// UpdateCoupon handles PATCH /api/coupons/:name
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	couponName := c.Param("name")
+
+	var req UpdateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var coupon models.Coupon
+	if err := h.db.Where("name = ?", couponName).First(&coupon).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coupon"})
+		return
+	}
+
+	if auth.Authenticated(c) && !auth.IsAdmin(c) && coupon.Brand != auth.Brand(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Coupon belongs to a different brand"})
+		return
 	}
 
-	// Check for PostgreSQL unique constraint violation (error code 23505)
-	var pqErr *pq.Error
-	if errors.As(err, &pqErr) {
-		return pqErr.Code == "23505" // unique_violation
+	if req.Status != nil {
+		coupon.Status = *req.Status
+	}
+	if req.StartsAt != nil {
+		coupon.StartsAt = req.StartsAt
+	}
+	if req.ExpiresAt != nil {
+		coupon.ExpiresAt = req.ExpiresAt
+	}
+	if req.BillingPeriods != nil {
+		coupon.BillingPeriods = req.BillingPeriods
 	}
 
-	// Fallback: check error message
-	errMsg := strings.ToLower(err.Error())
-	return strings.Contains(errMsg, "unique constraint") ||
-		strings.Contains(errMsg, "duplicate key") ||
-		strings.Contains(errMsg, "idx_user_coupon")
+	if err := h.db.Save(&coupon).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update coupon: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+// ClaimError is a custom error type for claim operations
+type ClaimError struct {
+	Message    string
+	StatusCode int
 }
 
+func (e *ClaimError) Error() string {
+	return e.Message
+}
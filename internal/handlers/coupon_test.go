@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flash-sale-coupon-system/internal/auth"
+	"flash-sale-coupon-system/internal/database"
+	"flash-sale-coupon-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type fakeSqliteDriver struct{}
+
+func (fakeSqliteDriver) Open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+}
+
+func (fakeSqliteDriver) IsUniqueViolation(err error) bool {
+	return false
+}
+
+func (fakeSqliteDriver) MigrateHook(db *gorm.DB) error {
+	return nil
+}
+
+func newTestHandler(t *testing.T) (*CouponHandler, database.Driver) {
+	t.Helper()
+
+	var driver database.Driver = fakeSqliteDriver{}
+	db, err := driver.Open(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Coupon{}, &models.Claim{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	return NewCouponHandler(db, driver, nil), driver
+}
+
+func setAuthContext(c *gin.Context, userID, brand string, admin bool) {
+	c.Set(auth.ContextUserID, userID)
+	c.Set(auth.ContextBrand, brand)
+	roles := []string{}
+	if admin {
+		roles = append(roles, auth.AdminRole)
+	}
+	c.Set(auth.ContextRoles, roles)
+}
+
+func TestGetCouponDetailsRejectsOtherBrand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, _ := newTestHandler(t)
+	h.db.Create(&models.Coupon{Name: "summer", Amount: 10, Brand: "acme"})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/coupons/summer", nil)
+	c.Params = gin.Params{{Key: "name", Value: "summer"}}
+	setAuthContext(c, "user-1", "other-brand", false)
+
+	h.GetCouponDetails(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for mismatched brand, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetCouponDetailsAllowsAdminAcrossBrands(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, _ := newTestHandler(t)
+	h.db.Create(&models.Coupon{Name: "summer", Amount: 10, Brand: "acme"})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/coupons/summer", nil)
+	c.Params = gin.Params{{Key: "name", Value: "summer"}}
+	setAuthContext(c, "admin-1", "other-brand", true)
+
+	h.GetCouponDetails(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for admin regardless of brand, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUpdateCouponRejectsOtherBrand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, _ := newTestHandler(t)
+	h.db.Create(&models.Coupon{Name: "summer", Amount: 10, Brand: "acme", Status: models.StatusActive})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := bytes.NewBufferString(`{"status":"revoked"}`)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/api/coupons/summer", body)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "name", Value: "summer"}}
+	setAuthContext(c, "user-1", "other-brand", false)
+
+	h.UpdateCoupon(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for mismatched brand, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var coupon models.Coupon
+	h.db.Where("name = ?", "summer").First(&coupon)
+	if coupon.Status != models.StatusActive {
+		t.Fatalf("coupon status should be untouched, got %s", coupon.Status)
+	}
+}
+
+func TestUpdateCouponAllowsAdminAcrossBrands(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, _ := newTestHandler(t)
+	h.db.Create(&models.Coupon{Name: "summer", Amount: 10, Brand: "acme", Status: models.StatusActive})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := bytes.NewBufferString(`{"status":"revoked"}`)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/api/coupons/summer", body)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "name", Value: "summer"}}
+	setAuthContext(c, "admin-1", "other-brand", true)
+
+	h.UpdateCoupon(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for admin regardless of brand, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var coupon models.Coupon
+	h.db.Where("name = ?", "summer").First(&coupon)
+	if coupon.Status != models.StatusRevoked {
+		t.Fatalf("expected coupon status revoked, got %s", coupon.Status)
+	}
+}
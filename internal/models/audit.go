@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog records an admin mutation for later review. IdempotencyKey, when
+// present, lets handlers detect a retried request and avoid re-applying it.
+// It's a pointer so omitted keys store as NULL rather than "": the unique
+// index below only blocks a genuine repeat of (action, target, key), and
+// most SQL backends treat NULL as distinct from every other NULL in a
+// unique index, so keyless audit rows never collide with each other.
+type AuditLog struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	Actor          string          `gorm:"index;not null" json:"actor"`
+	Action         string          `gorm:"uniqueIndex:idx_audit_idempotency;not null" json:"action"`
+	Target         string          `gorm:"uniqueIndex:idx_audit_idempotency;not null" json:"target"`
+	Payload        json.RawMessage `gorm:"type:json" json:"payload,omitempty"`
+	IdempotencyKey *string         `gorm:"uniqueIndex:idx_audit_idempotency" json:"-"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
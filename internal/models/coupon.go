@@ -6,21 +6,58 @@ import (
 	"gorm.io/gorm"
 )
 
+// CouponStatus is the lifecycle state of a coupon.
+type CouponStatus string
+
+const (
+	StatusDraft     CouponStatus = "draft"
+	StatusActive    CouponStatus = "active"
+	StatusExpired   CouponStatus = "expired"
+	StatusExhausted CouponStatus = "exhausted"
+	StatusRevoked   CouponStatus = "revoked"
+)
+
 // Coupon represents a coupon in the system
 type Coupon struct {
-	ID        uint           `gorm:"primaryKey" json:"-"`
-	Name      string         `gorm:"uniqueIndex;not null" json:"name"`
-	Amount    int            `gorm:"not null" json:"amount"`
+	ID     uint         `gorm:"primaryKey" json:"-"`
+	Name   string       `gorm:"uniqueIndex;not null" json:"name"`
+	Amount int          `gorm:"not null" json:"amount"`
+	Status CouponStatus `gorm:"not null;default:active" json:"status"`
+
+	// Brand scopes the coupon to a tenant; non-admin callers only see and
+	// create coupons matching their JWT's brand claim.
+	Brand string `gorm:"index" json:"brand,omitempty"`
+
+	// StartsAt and ExpiresAt bound the claim window; either may be nil for an
+	// open-ended start or no expiration.
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// BillingPeriods, when set, is the number of days between automatic stock
+	// refills (mirrors the periodic-coupon pattern); nil means the amount is
+	// granted once and never replenished.
+	BillingPeriods *int       `json:"billing_periods,omitempty"`
+	LastRefilledAt *time.Time `json:"-"`
+
+	// CurrentPeriod increments each time the scheduler refills this coupon's
+	// stock. Claims are tagged with the period they were made in so a refill
+	// resets "remaining" for everyone without deleting claim history, and a
+	// user can claim again once a new period starts.
+	CurrentPeriod int `gorm:"not null;default:0" json:"-"`
+
 	CreatedAt time.Time      `json:"-"`
 	UpdatedAt time.Time      `json:"-"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// Claim represents a coupon claim by a user
+// Claim represents a coupon claim by a user. Period ties the claim to the
+// coupon's CurrentPeriod at claim time, so the uniqueness constraint only
+// blocks a second claim within the same period, not across a refill.
 type Claim struct {
 	ID         uint           `gorm:"primaryKey" json:"-"`
 	UserID     string         `gorm:"uniqueIndex:idx_user_coupon;not null" json:"user_id"`
 	CouponName string         `gorm:"uniqueIndex:idx_user_coupon;not null;index" json:"coupon_name"`
+	Period     int            `gorm:"uniqueIndex:idx_user_coupon;not null;default:0" json:"-"`
 	CreatedAt  time.Time      `json:"-"`
 	UpdatedAt  time.Time      `json:"-"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
@@ -28,9 +65,12 @@ type Claim struct {
 
 // CouponResponse represents the response structure for GetCouponDetails
 type CouponResponse struct {
-	Name           string   `json:"name"`
-	Amount         int      `json:"amount"`
-	RemainingAmount int     `json:"remaining_amount"`
-	ClaimedBy      []string `json:"claimed_by"`
+	Name              string       `json:"name"`
+	Amount            int          `json:"amount"`
+	RemainingAmount   int          `json:"remaining_amount"`
+	ClaimedBy         []string     `json:"claimed_by"`
+	Status            CouponStatus `json:"status"`
+	StartsAt          *time.Time   `json:"starts_at,omitempty"`
+	ExpiresAt         *time.Time   `json:"expires_at,omitempty"`
+	TimeRemainingSecs *int64       `json:"time_remaining_seconds,omitempty"`
 }
-
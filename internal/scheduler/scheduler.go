@@ -0,0 +1,150 @@
+// Package scheduler runs background jobs that keep coupon lifecycle state in
+// sync with the clock: expiring coupons whose window has closed, activating
+// drafts whose window has opened, and refilling stock for coupons on a
+// billing period.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"flash-sale-coupon-system/internal/claimengine"
+	"flash-sale-coupon-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Scheduler periodically transitions coupon statuses and performs periodic
+// refills. Construct one with New and call Start; Stop ends the loop.
+type Scheduler struct {
+	db       *gorm.DB
+	engine   *claimengine.Engine
+	interval time.Duration
+	logger   *log.Logger
+	stop     chan struct{}
+}
+
+// New creates a Scheduler that ticks every interval. engine may be nil; when
+// set, periodic refills reseed the Redis fast path alongside Postgres.
+func New(db *gorm.DB, engine *claimengine.Engine, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		engine:   engine,
+		interval: interval,
+		logger:   log.New(log.Writer(), "[scheduler] ", log.LstdFlags),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler loop in a background goroutine.
+func (s *Scheduler) Start() {
+	go s.run()
+}
+
+// Stop ends the scheduler loop.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+}
+
+func (s *Scheduler) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.tick(time.Now())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) tick(now time.Time) {
+	if err := s.transitionStatuses(now); err != nil {
+		s.logger.Printf("status transition failed: %v", err)
+	}
+	if err := s.refillDue(now); err != nil {
+		s.logger.Printf("refill failed: %v", err)
+	}
+}
+
+// transitionStatuses expires coupons whose window has closed and activates
+// draft coupons whose window has opened. This job never sets exhausted or
+// revoked; those statuses change only through UpdateCoupon (PATCH
+// /api/coupons/:name).
+func (s *Scheduler) transitionStatuses(now time.Time) error {
+	if err := s.db.Model(&models.Coupon{}).
+		Where("status IN ? AND expires_at IS NOT NULL AND expires_at <= ?", []models.CouponStatus{models.StatusActive, models.StatusDraft}, now).
+		Update("status", models.StatusExpired).Error; err != nil {
+		return err
+	}
+
+	return s.db.Model(&models.Coupon{}).
+		Where("status = ? AND (starts_at IS NULL OR starts_at <= ?)", models.StatusDraft, now).
+		Update("status", models.StatusActive).Error
+}
+
+// refillDue resets stock for coupons with BillingPeriods set, once per
+// elapsed period. A refill advances Coupon.CurrentPeriod rather than
+// touching existing Claim rows, so both the Redis fast path and the
+// Postgres fallback see stock as reset (claims are scoped to the period
+// they were made in) without losing claim history. The check for whether a
+// period has elapsed and the update of LastRefilledAt/CurrentPeriod happen
+// inside one row-locked transaction, so a scheduler restart mid-period
+// re-evaluates the same anchor instead of refilling twice.
+func (s *Scheduler) refillDue(now time.Time) error {
+	var candidates []models.Coupon
+	if err := s.db.Where("billing_periods IS NOT NULL AND status = ?", models.StatusActive).Find(&candidates).Error; err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		refilled, err := s.refillOne(now, candidate.ID)
+		if err != nil {
+			return err
+		}
+		if refilled && s.engine != nil && s.engine.Available() {
+			if err := s.engine.CreateCoupon(context.Background(), candidate.Name, candidate.Amount); err != nil {
+				s.logger.Printf("reseed after refill failed for %s: %v", candidate.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheduler) refillOne(now time.Time, couponID uint) (bool, error) {
+	refilled := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var coupon models.Coupon
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", couponID).First(&coupon).Error; err != nil {
+			return err
+		}
+
+		if coupon.BillingPeriods == nil {
+			return nil
+		}
+
+		anchor := coupon.CreatedAt
+		if coupon.LastRefilledAt != nil {
+			anchor = *coupon.LastRefilledAt
+		}
+
+		period := time.Duration(*coupon.BillingPeriods) * 24 * time.Hour
+		if period <= 0 || now.Before(anchor.Add(period)) {
+			return nil
+		}
+
+		refilled = true
+		return tx.Model(&coupon).Updates(map[string]interface{}{
+			"last_refilled_at": now,
+			"current_period":   coupon.CurrentPeriod + 1,
+		}).Error
+	})
+
+	return refilled, err
+}
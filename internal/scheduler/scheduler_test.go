@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"flash-sale-coupon-system/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Coupon{}, &models.Claim{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	return db
+}
+
+func intPtr(v int) *int { return &v }
+
+// TestRefillOneAdvancesPeriodOnce proves a single elapsed period only
+// advances CurrentPeriod once, even if refillOne is invoked again before the
+// next period has elapsed - the bug the reviewer flagged would otherwise let
+// every scheduler tick reset stock.
+func TestRefillOneAdvancesPeriodOnce(t *testing.T) {
+	db := newTestDB(t)
+	s := New(db, nil, time.Hour)
+
+	created := time.Now().Add(-25 * time.Hour)
+	coupon := models.Coupon{
+		Name:           "daily",
+		Amount:         5,
+		Status:         models.StatusActive,
+		BillingPeriods: intPtr(1),
+	}
+	if err := db.Create(&coupon).Error; err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	db.Model(&coupon).Update("created_at", created)
+	db.First(&coupon, coupon.ID)
+
+	now := time.Now()
+
+	refilled, err := s.refillOne(now, coupon.ID)
+	if err != nil {
+		t.Fatalf("refillOne: %v", err)
+	}
+	if !refilled {
+		t.Fatal("expected first refillOne call to refill a coupon past its billing period")
+	}
+
+	var afterFirst models.Coupon
+	db.First(&afterFirst, coupon.ID)
+	if afterFirst.CurrentPeriod != 1 {
+		t.Fatalf("expected CurrentPeriod 1 after first refill, got %d", afterFirst.CurrentPeriod)
+	}
+
+	refilledAgain, err := s.refillOne(now, coupon.ID)
+	if err != nil {
+		t.Fatalf("refillOne (second call): %v", err)
+	}
+	if refilledAgain {
+		t.Fatal("expected second refillOne call within the same period to be a no-op")
+	}
+
+	var afterSecond models.Coupon
+	db.First(&afterSecond, coupon.ID)
+	if afterSecond.CurrentPeriod != 1 {
+		t.Fatalf("expected CurrentPeriod to stay at 1, got %d", afterSecond.CurrentPeriod)
+	}
+}
+
+// TestRefillResetsDBFallbackRemainingStock proves a refill makes stock
+// available again on the Postgres/DB fallback path, not just in Redis: once
+// a coupon is fully claimed in period 0, a refill leaves zero claims counted
+// against the new period (the count the fallback path compares to Amount)
+// while the period-0 claim row is kept.
+func TestRefillResetsDBFallbackRemainingStock(t *testing.T) {
+	db := newTestDB(t)
+	s := New(db, nil, time.Hour)
+
+	created := time.Now().Add(-25 * time.Hour)
+	coupon := models.Coupon{
+		Name:           "daily",
+		Amount:         1,
+		Status:         models.StatusActive,
+		BillingPeriods: intPtr(1),
+	}
+	if err := db.Create(&coupon).Error; err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	db.Model(&coupon).Update("created_at", created)
+	db.First(&coupon, coupon.ID)
+
+	if err := db.Create(&models.Claim{UserID: "user-1", CouponName: coupon.Name, Period: 0}).Error; err != nil {
+		t.Fatalf("create claim: %v", err)
+	}
+
+	var claimCountBefore int64
+	db.Model(&models.Claim{}).Where("coupon_name = ? AND period = ?", coupon.Name, coupon.CurrentPeriod).Count(&claimCountBefore)
+	if int(claimCountBefore) < coupon.Amount {
+		t.Fatal("test setup: coupon should be exhausted before refill")
+	}
+
+	refilled, err := s.refillOne(time.Now(), coupon.ID)
+	if err != nil {
+		t.Fatalf("refillOne: %v", err)
+	}
+	if !refilled {
+		t.Fatal("expected refillOne to refill the exhausted coupon")
+	}
+
+	var afterRefill models.Coupon
+	db.First(&afterRefill, coupon.ID)
+
+	var claimCountAfter int64
+	db.Model(&models.Claim{}).Where("coupon_name = ? AND period = ?", coupon.Name, afterRefill.CurrentPeriod).Count(&claimCountAfter)
+	if claimCountAfter != 0 {
+		t.Fatalf("expected zero claims counted against the new period, got %d", claimCountAfter)
+	}
+
+	var totalClaims int64
+	db.Model(&models.Claim{}).Where("coupon_name = ?", coupon.Name).Count(&totalClaims)
+	if totalClaims != 1 {
+		t.Fatalf("expected the period-0 claim row to be preserved for history, got %d rows", totalClaims)
+	}
+}
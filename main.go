@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
+	"flash-sale-coupon-system/internal/admin"
+	"flash-sale-coupon-system/internal/auth"
+	"flash-sale-coupon-system/internal/claimengine"
 	"flash-sale-coupon-system/internal/config"
 	"flash-sale-coupon-system/internal/database"
 	"flash-sale-coupon-system/internal/handlers"
+	"flash-sale-coupon-system/internal/scheduler"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,31 +22,68 @@ func main() {
 	cfg := config.Load()
 
 	// Initialize database
-	db, err := database.Initialize(cfg.DatabaseURL)
+	db, driver, err := database.Initialize(cfg.DBDriver, cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
 	// Auto-migrate database schema
-	if err := database.Migrate(db); err != nil {
+	if err := database.Migrate(db, driver); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	// Initialize the Redis-backed claim engine and warm it from Postgres. If
+	// Redis is unreachable, the engine reports itself unavailable and the
+	// handlers fall back to the plain GORM transaction path.
+	engine := claimengine.NewEngine(db, driver, cfg.RedisAddr)
+	if engine.Available() {
+		if err := engine.Warmup(context.Background()); err != nil {
+			log.Printf("claim engine warmup failed, falling back to direct DB path: %v", err)
+		}
+	}
+
 	// Initialize handlers
-	couponHandler := handlers.NewCouponHandler(db)
+	couponHandler := handlers.NewCouponHandler(db, driver, engine)
+	adminHandler := admin.NewHandler(db, driver, engine)
+
+	// Start the lifecycle scheduler that expires/activates coupons and
+	// refills stock on a billing period.
+	lifecycleScheduler := scheduler.New(db, engine, time.Minute)
+	lifecycleScheduler.Start()
+	defer lifecycleScheduler.Stop()
 
 	// Setup router
 	router := gin.Default()
 
 	// API routes
 	api := router.Group("/api")
+	if cfg.AuthEnabled {
+		api.Use(auth.Middleware(cfg.JWTSecret))
+	}
 	{
 		coupons := api.Group("/coupons")
 		{
 			coupons.POST("", couponHandler.CreateCoupon)
 			coupons.POST("/claim", couponHandler.ClaimCoupon)
+			coupons.GET("", couponHandler.ListCoupons)
 			coupons.GET("/:name", couponHandler.GetCouponDetails)
+			coupons.PATCH("/:name", couponHandler.UpdateCoupon)
+		}
+	}
+
+	// Admin routes always require an authenticated admin, regardless of the
+	// AUTH_ENABLED toggle for the public API.
+	adminGroup := router.Group("/admin")
+	adminGroup.Use(auth.Middleware(cfg.JWTSecret), auth.RequireAdmin())
+	{
+		adminCoupons := adminGroup.Group("/coupons")
+		{
+			adminCoupons.GET("", adminHandler.ListCoupons)
+			adminCoupons.POST("/:name/revoke-claim", adminHandler.RevokeClaim)
+			adminCoupons.POST("/:name/refill", adminHandler.Refill)
+			adminCoupons.POST("/:name/bulk-grant", adminHandler.BulkGrant)
 		}
+		adminGroup.GET("/audit", adminHandler.ListAuditLogs)
 	}
 
 	// Health check endpoint